@@ -0,0 +1,173 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pulsar
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+
+	"github.com/streamnative/pulsar-admin-go/pkg/pulsar/utils"
+)
+
+// maxLZ4DecompressSize bounds the output buffer growth in lz4Decompressor.Decode when the
+// broker doesn't report an uncompressed size, so a malformed payload can't force an
+// unbounded allocation.
+const maxLZ4DecompressSize = 64 << 20 // 64 MiB
+
+// Decompressor decodes a batch or single-message payload that was compressed by a
+// producer before publishing, mirroring the codecs apache/pulsar-client-go ships with.
+type Decompressor interface {
+	// Decode returns the uncompressed form of compressed. uncompressedSize is the size
+	// reported by the message metadata and is used to pre-size the output buffer; it may
+	// be 0 if the broker didn't report one.
+	Decode(compressed []byte, uncompressedSize int) ([]byte, error)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[utils.CompressionType]Decompressor{
+		utils.CompressionType_NONE:   noneDecompressor{},
+		utils.CompressionType_LZ4:    lz4Decompressor{},
+		utils.CompressionType_ZLIB:   zlibDecompressor{},
+		utils.CompressionType_ZSTD:   zstdDecompressor{},
+		utils.CompressionType_SNAPPY: snappyDecompressor{},
+	}
+)
+
+// RegisterCompressionCodec registers the Decompressor used for compressionType,
+// replacing the built-in codec if one is already registered. It allows callers to plug
+// in an alternative implementation without forking this package.
+func RegisterCompressionCodec(compressionType utils.CompressionType, codec Decompressor) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[compressionType] = codec
+}
+
+func decompressorFor(compressionType utils.CompressionType) (Decompressor, error) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	codec, ok := codecs[compressionType]
+	if !ok {
+		return nil, fmt.Errorf("pulsar: no decompressor registered for compression type %v", compressionType)
+	}
+	return codec, nil
+}
+
+type noneDecompressor struct{}
+
+func (noneDecompressor) Decode(compressed []byte, _ int) ([]byte, error) {
+	return compressed, nil
+}
+
+type lz4Decompressor struct{}
+
+func (lz4Decompressor) Decode(compressed []byte, uncompressedSize int) ([]byte, error) {
+	size := uncompressedSize
+	if size <= 0 {
+		// The broker didn't report an uncompressed size. lz4's block format (unlike
+		// zlib/zstd/snappy) needs the output buffer sized upfront, so grow it until it's
+		// big enough rather than failing outright.
+		size = len(compressed) * 4
+		if size == 0 {
+			size = 1024
+		}
+	}
+
+	for {
+		out := make([]byte, size)
+		n, err := lz4.UncompressBlock(compressed, out)
+		if err == nil {
+			return out[:n], nil
+		}
+		if !errors.Is(err, lz4.ErrInvalidSourceShortBuffer) {
+			return nil, fmt.Errorf("pulsar: lz4 decompress: %w", err)
+		}
+		if size >= maxLZ4DecompressSize {
+			return nil, fmt.Errorf("pulsar: lz4 decompress: output exceeds %d bytes", maxLZ4DecompressSize)
+		}
+		size *= 2
+		if size > maxLZ4DecompressSize {
+			size = maxLZ4DecompressSize
+		}
+	}
+}
+
+type zlibDecompressor struct{}
+
+func (zlibDecompressor) Decode(compressed []byte, _ int) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("pulsar: zlib decompress: %w", err)
+	}
+	defer r.Close()
+
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("pulsar: zlib decompress: %w", err)
+	}
+	return out, nil
+}
+
+type zstdDecompressor struct{}
+
+func (zstdDecompressor) Decode(compressed []byte, _ int) ([]byte, error) {
+	d, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, fmt.Errorf("pulsar: zstd decompress: %w", err)
+	}
+	defer d.Close()
+
+	out, err := d.DecodeAll(compressed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("pulsar: zstd decompress: %w", err)
+	}
+	return out, nil
+}
+
+type snappyDecompressor struct{}
+
+func (snappyDecompressor) Decode(compressed []byte, _ int) ([]byte, error) {
+	out, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, fmt.Errorf("pulsar: snappy decompress: %w", err)
+	}
+	return out, nil
+}
+
+// decompressPayload decodes data according to the compression type declared in meta,
+// returning data unchanged when meta is nil or reports CompressionType_NONE.
+func decompressPayload(meta *utils.MessageMetadata, data []byte) ([]byte, error) {
+	if meta == nil || meta.GetCompression() == utils.CompressionType_NONE {
+		return data, nil
+	}
+
+	codec, err := decompressorFor(meta.GetCompression())
+	if err != nil {
+		return nil, err
+	}
+	return codec.Decode(data, int(meta.GetUncompressedSize()))
+}
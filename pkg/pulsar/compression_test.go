@@ -0,0 +1,125 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pulsar
+
+import (
+	"bytes"
+	"compress/zlib"
+	"strings"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// decompressorFixture repeats its phrase enough times that every codec under test,
+// including lz4 (which declines to emit a block at all for short, low-redundancy input),
+// actually produces compressed output to round-trip.
+var decompressorFixture = []byte(strings.Repeat("the quick brown fox jumps over the lazy dog, ", 50))
+
+func TestLZ4DecompressorRoundTrip(t *testing.T) {
+	src := decompressorFixture
+	compressed := make([]byte, len(src))
+	n, err := lz4.CompressBlock(src, compressed, nil)
+	if err != nil {
+		t.Fatalf("compressing fixture: %v", err)
+	}
+	compressed = compressed[:n]
+
+	out, err := (lz4Decompressor{}).Decode(compressed, len(src))
+	if err != nil {
+		t.Fatalf("Decode with known size: %v", err)
+	}
+	if !bytes.Equal(out, src) {
+		t.Fatalf("Decode with known size = %q, want %q", out, src)
+	}
+
+	out, err = (lz4Decompressor{}).Decode(compressed, 0)
+	if err != nil {
+		t.Fatalf("Decode with unknown size: %v", err)
+	}
+	if !bytes.Equal(out, src) {
+		t.Fatalf("Decode with unknown size = %q, want %q", out, src)
+	}
+}
+
+func TestZlibDecompressorRoundTrip(t *testing.T) {
+	src := decompressorFixture
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	if _, err := w.Write(src); err != nil {
+		t.Fatalf("compressing fixture: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("closing zlib writer: %v", err)
+	}
+
+	out, err := (zlibDecompressor{}).Decode(buf.Bytes(), len(src))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !bytes.Equal(out, src) {
+		t.Fatalf("Decode = %q, want %q", out, src)
+	}
+}
+
+func TestZstdDecompressorRoundTrip(t *testing.T) {
+	src := decompressorFixture
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("building zstd encoder: %v", err)
+	}
+	compressed := enc.EncodeAll(src, nil)
+	if err := enc.Close(); err != nil {
+		t.Fatalf("closing zstd encoder: %v", err)
+	}
+
+	out, err := (zstdDecompressor{}).Decode(compressed, len(src))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !bytes.Equal(out, src) {
+		t.Fatalf("Decode = %q, want %q", out, src)
+	}
+}
+
+func TestSnappyDecompressorRoundTrip(t *testing.T) {
+	src := decompressorFixture
+	compressed := snappy.Encode(nil, src)
+
+	out, err := (snappyDecompressor{}).Decode(compressed, len(src))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !bytes.Equal(out, src) {
+		t.Fatalf("Decode = %q, want %q", out, src)
+	}
+}
+
+func TestNoneDecompressorReturnsInputUnchanged(t *testing.T) {
+	src := decompressorFixture
+
+	out, err := (noneDecompressor{}).Decode(src, len(src))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !bytes.Equal(out, src) {
+		t.Fatalf("Decode = %q, want %q", out, src)
+	}
+}
@@ -0,0 +1,159 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pulsar
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/streamnative/pulsar-admin-go/pkg/pulsar/utils"
+)
+
+// These tests exercise handleResp, the function PeekMessages/PeekMessagesIter ultimately
+// call for every fetched position, rather than the Decompressor codecs in isolation: they
+// build the actual *http.Response shape the broker sends (header-advertised metadata for a
+// single message, embedded metadata for a batch) and check the decompressed, batch-split
+// result handleResp returns.
+
+func TestHandleRespHeaderMetadataLZ4SingleMessage(t *testing.T) {
+	src := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog, ", 50))
+	compressed := make([]byte, len(src))
+	n, err := lz4.CompressBlock(src, compressed, nil)
+	if err != nil {
+		t.Fatalf("compressing fixture: %v", err)
+	}
+	compressed = compressed[:n]
+
+	compression := utils.CompressionType_LZ4
+	uncompressedSize := uint32(len(src))
+	metaBytes, err := proto.Marshal(&utils.MessageMetadata{
+		Compression:      &compression,
+		UncompressedSize: &uncompressedSize,
+	})
+	if err != nil {
+		t.Fatalf("marshalling metadata: %v", err)
+	}
+
+	header := http.Header{}
+	header.Set("X-Pulsar-Message-ID", "1:2:0")
+	header.Set(MessageMetadataHeader, base64.StdEncoding.EncodeToString(metaBytes))
+
+	resp := &http.Response{
+		Header: header,
+		Body:   ioutil.NopCloser(bytes.NewReader(compressed)),
+	}
+
+	msgs, err := handleResp(utils.TopicName{}, resp, nil)
+	if err != nil {
+		t.Fatalf("handleResp: %v", err)
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(msgs))
+	}
+	if !bytes.Equal(msgs[0].Payload, src) {
+		t.Fatalf("payload = %q, want %q", msgs[0].Payload, src)
+	}
+	if msgs[0].MessageID.LedgerID != 1 || msgs[0].MessageID.EntryID != 2 {
+		t.Fatalf("unexpected message id: %+v", msgs[0].MessageID)
+	}
+}
+
+func TestHandleRespEmbeddedMetadataZstdBatch(t *testing.T) {
+	entries := [][]byte{
+		[]byte("first entry in the batch"),
+		[]byte("second entry in the batch"),
+	}
+
+	var plain bytes.Buffer
+	for _, payload := range entries {
+		payloadSize := int32(len(payload))
+		singleMetaBytes, err := proto.Marshal(&utils.SingleMessageMetadata{PayloadSize: &payloadSize})
+		if err != nil {
+			t.Fatalf("marshalling single message metadata: %v", err)
+		}
+
+		var sizeBuf [4]byte
+		binary.BigEndian.PutUint32(sizeBuf[:], uint32(len(singleMetaBytes)))
+		plain.Write(sizeBuf[:])
+		plain.Write(singleMetaBytes)
+		plain.Write(payload)
+	}
+
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("building zstd encoder: %v", err)
+	}
+	compressedBatch := enc.EncodeAll(plain.Bytes(), nil)
+	if err := enc.Close(); err != nil {
+		t.Fatalf("closing zstd encoder: %v", err)
+	}
+
+	compression := utils.CompressionType_ZSTD
+	uncompressedSize := uint32(plain.Len())
+	metaBytes, err := proto.Marshal(&utils.MessageMetadata{
+		Compression:      &compression,
+		UncompressedSize: &uncompressedSize,
+	})
+	if err != nil {
+		t.Fatalf("marshalling metadata: %v", err)
+	}
+
+	var body bytes.Buffer
+	var metaSizeBuf [4]byte
+	binary.BigEndian.PutUint32(metaSizeBuf[:], uint32(len(metaBytes)))
+	body.Write(metaSizeBuf[:])
+	body.Write(metaBytes)
+	body.Write(compressedBatch)
+
+	header := http.Header{}
+	header.Set("X-Pulsar-Message-ID", "5:9:1")
+	header.Set(BatchHeader, "2")
+
+	resp := &http.Response{
+		Header: header,
+		Body:   ioutil.NopCloser(bytes.NewReader(body.Bytes())),
+	}
+
+	msgs, err := handleResp(utils.TopicName{}, resp, nil)
+	if err != nil {
+		t.Fatalf("handleResp: %v", err)
+	}
+	if len(msgs) != len(entries) {
+		t.Fatalf("got %d messages, want %d", len(msgs), len(entries))
+	}
+	for i, want := range entries {
+		got := msgs[i]
+		if !bytes.Equal(got.Payload, want) {
+			t.Fatalf("message %d payload = %q, want %q", i, got.Payload, want)
+		}
+		if got.MessageID.BatchIndex == nil || *got.MessageID.BatchIndex != i {
+			t.Fatalf("message %d batch index = %v, want %d", i, got.MessageID.BatchIndex, i)
+		}
+		if got.MessageID.BatchSize == nil || *got.MessageID.BatchSize != len(entries) {
+			t.Fatalf("message %d batch size = %v, want %d", i, got.MessageID.BatchSize, len(entries))
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pulsar
+
+import (
+	"context"
+	"io"
+
+	"github.com/streamnative/pulsar-admin-go/pkg/pulsar/utils"
+)
+
+// MessageIterator lazily walks the messages peeked from a topic subscription, fetching
+// the next position only once the previously fetched batch entries have been consumed.
+// This avoids buffering the full peeked set up front, unlike PeekMessages.
+type MessageIterator interface {
+	// Next returns the next peeked message, fetching from the broker as needed. It
+	// returns io.EOF once at least the requested number of messages has been yielded.
+	// Every entry of a fetched batch is yielded, so the total can exceed that number.
+	Next(ctx context.Context) (*utils.Message, error)
+
+	// Close releases any resources held by the iterator. It is safe to call Close
+	// without exhausting Next, and safe to call it more than once.
+	Close()
+}
+
+type messageIterator struct {
+	subs  *subscriptions
+	topic utils.TopicName
+	sName string
+
+	remaining int
+	pos       int
+	buffered  []*utils.Message
+	closed    bool
+}
+
+func (s *subscriptions) PeekMessagesIter(topic utils.TopicName, sName string, n int) (MessageIterator, error) {
+	return &messageIterator{
+		subs:      s,
+		topic:     topic,
+		sName:     sName,
+		remaining: n,
+		pos:       1,
+	}, nil
+}
+
+func (it *messageIterator) Next(ctx context.Context) (*utils.Message, error) {
+	if it.closed {
+		return nil, io.EOF
+	}
+
+	for len(it.buffered) == 0 {
+		if it.remaining <= 0 {
+			it.Close()
+			return nil, io.EOF
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		m, err := it.subs.peekNthMessage(ctx, it.topic, it.sName, it.pos)
+		if err != nil {
+			return nil, err
+		}
+		it.pos++
+		it.buffered = m
+		it.remaining -= len(m)
+	}
+
+	msg := it.buffered[0]
+	it.buffered = it.buffered[1:]
+	return msg, nil
+}
+
+func (it *messageIterator) Close() {
+	it.closed = true
+	it.buffered = nil
+}
@@ -19,7 +19,11 @@ package pulsar
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/binary"
+	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -36,134 +40,261 @@ type Subscriptions interface {
 	// Create a new subscription on a topic
 	Create(utils.TopicName, string, utils.MessageID) error
 
+	// CreateWithContext is like Create but allows passing a context.Context to bound or
+	// cancel the underlying HTTP call.
+	CreateWithContext(context.Context, utils.TopicName, string, utils.MessageID) error
+
 	// Delete a subscription.
 	// Delete a persistent subscription from a topic. There should not be any active consumers on the subscription
 	Delete(utils.TopicName, string) error
 
+	// DeleteWithContext is like Delete but allows passing a context.Context to bound or
+	// cancel the underlying HTTP call.
+	DeleteWithContext(context.Context, utils.TopicName, string) error
+
 	// List returns the list of subscriptions
 	List(utils.TopicName) ([]string, error)
 
+	// ListWithContext is like List but allows passing a context.Context to bound or
+	// cancel the underlying HTTP call.
+	ListWithContext(context.Context, utils.TopicName) ([]string, error)
+
 	// ResetCursorToMessageID resets cursor position on a topic subscription
 	// @param
-	// messageID reset subscription to messageId (or previous nearest messageId if given messageId is not valid)
+	// messageID reset subscription to messageId (or previous nearest messageId if given messageId is not valid).
+	// When messageID.BatchIndex is set, the cursor is positioned at that exact entry within the batch rather
+	// than at the start of the batch.
 	ResetCursorToMessageID(utils.TopicName, string, utils.MessageID) error
 
+	// ResetCursorToMessageIDWithContext is like ResetCursorToMessageID but allows passing a
+	// context.Context to bound or cancel the underlying HTTP call.
+	ResetCursorToMessageIDWithContext(context.Context, utils.TopicName, string, utils.MessageID) error
+
 	// ResetCursorToTimestamp resets cursor position on a topic subscription
 	// @param
 	// time reset subscription to position closest to time in ms since epoch
 	ResetCursorToTimestamp(utils.TopicName, string, int64) error
 
+	// ResetCursorToTimestampWithContext is like ResetCursorToTimestamp but allows passing a
+	// context.Context to bound or cancel the underlying HTTP call.
+	ResetCursorToTimestampWithContext(context.Context, utils.TopicName, string, int64) error
+
 	// ClearBacklog skips all messages on a topic subscription
 	ClearBacklog(utils.TopicName, string) error
 
+	// ClearBacklogWithContext is like ClearBacklog but allows passing a context.Context to
+	// bound or cancel the underlying HTTP call.
+	ClearBacklogWithContext(context.Context, utils.TopicName, string) error
+
 	// SkipMessages skips messages on a topic subscription
 	SkipMessages(utils.TopicName, string, int64) error
 
+	// SkipMessagesWithContext is like SkipMessages but allows passing a context.Context to
+	// bound or cancel the underlying HTTP call.
+	SkipMessagesWithContext(context.Context, utils.TopicName, string, int64) error
+
 	// ExpireMessages expires all messages older than given N (expireTimeInSeconds) seconds for a given subscription
 	ExpireMessages(utils.TopicName, string, int64) error
 
+	// ExpireMessagesWithContext is like ExpireMessages but allows passing a context.Context
+	// to bound or cancel the underlying HTTP call.
+	ExpireMessagesWithContext(context.Context, utils.TopicName, string, int64) error
+
 	// ExpireAllMessages expires all messages older than given N (expireTimeInSeconds) seconds for all
 	// subscriptions of the persistent-topic
 	ExpireAllMessages(utils.TopicName, int64) error
 
+	// ExpireAllMessagesWithContext is like ExpireAllMessages but allows passing a
+	// context.Context to bound or cancel the underlying HTTP call.
+	ExpireAllMessagesWithContext(context.Context, utils.TopicName, int64) error
+
 	// PeekMessages peeks messages from a topic subscription
 	PeekMessages(utils.TopicName, string, int) ([]*utils.Message, error)
+
+	// PeekMessagesWithContext is like PeekMessages but allows passing a context.Context to
+	// bound or cancel the peek loop; cancellation is honored between fetched positions so a
+	// stuck topic can no longer hang PeekMessages indefinitely.
+	PeekMessagesWithContext(context.Context, utils.TopicName, string, int) ([]*utils.Message, error)
+
+	// PeekMessagesIter returns a MessageIterator that lazily peeks n messages, fetching
+	// each position from the broker only as the caller consumes it. All entries of a
+	// batched position are yielded, so the total can exceed n. Prefer this
+	// over PeekMessages when n is large or payloads are big, since it avoids buffering the
+	// full result set in memory.
+	PeekMessagesIter(utils.TopicName, string, int) (MessageIterator, error)
+
+	// AckMessage and AckCumulative were requested alongside batch-index-aware cursor
+	// reset (see BatchIndex on utils.MessageID) but are not part of this interface: the
+	// persistent-topics admin REST API has no per-subscription ack endpoint to POST to,
+	// only the resetcursor/skip/expireMessages family this interface already covers.
+	// Acking individual messages is a consumer-protocol operation, not an admin one, in
+	// every broker version this client has been checked against. Raised back to the
+	// requester rather than implemented against a nonexistent endpoint; revisit if a
+	// future broker adds one.
 }
 
 type subscriptions struct {
-	client   *client
-	basePath string
-	SubPath  string
+	client          *client
+	basePath        string
+	SubPath         string
+	cryptoKeyReader CryptoKeyReader
+}
+
+// SubscriptionsOption configures the Subscriptions client returned by client.Subscriptions.
+type SubscriptionsOption func(*subscriptions)
+
+// WithCryptoKeyReader attaches a CryptoKeyReader used to decrypt end-to-end encrypted
+// messages returned by PeekMessages. Without it, peeking an encrypted topic fails.
+func WithCryptoKeyReader(reader CryptoKeyReader) SubscriptionsOption {
+	return func(s *subscriptions) {
+		s.cryptoKeyReader = reader
+	}
 }
 
 // Subscriptions is used to access the subscriptions endpoints
-func (c *client) Subscriptions() Subscriptions {
-	return &subscriptions{
+func (c *client) Subscriptions(opts ...SubscriptionsOption) Subscriptions {
+	s := &subscriptions{
 		client:   c,
 		basePath: "",
 		SubPath:  "subscription",
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *subscriptions) Create(topic utils.TopicName, sName string, messageID utils.MessageID) error {
+	return s.CreateWithContext(context.Background(), topic, sName, messageID)
+}
+
+func (s *subscriptions) CreateWithContext(ctx context.Context, topic utils.TopicName, sName string,
+	messageID utils.MessageID) error {
 	endpoint := s.client.endpoint(s.basePath, topic.GetRestPath(), s.SubPath, url.QueryEscape(sName))
-	return s.client.put(endpoint, messageID)
+	return s.doWithContext(ctx, http.MethodPut, endpoint, messageID, nil)
 }
 
 func (s *subscriptions) Delete(topic utils.TopicName, sName string) error {
+	return s.DeleteWithContext(context.Background(), topic, sName)
+}
+
+func (s *subscriptions) DeleteWithContext(ctx context.Context, topic utils.TopicName, sName string) error {
 	endpoint := s.client.endpoint(s.basePath, topic.GetRestPath(), s.SubPath, url.QueryEscape(sName))
-	return s.client.delete(endpoint)
+	return s.doWithContext(ctx, http.MethodDelete, endpoint, nil, nil)
 }
 
 func (s *subscriptions) List(topic utils.TopicName) ([]string, error) {
+	return s.ListWithContext(context.Background(), topic)
+}
+
+func (s *subscriptions) ListWithContext(ctx context.Context, topic utils.TopicName) ([]string, error) {
 	endpoint := s.client.endpoint(s.basePath, topic.GetRestPath(), "subscriptions")
 	var list []string
-	return list, s.client.get(endpoint, &list)
+	return list, s.doWithContext(ctx, http.MethodGet, endpoint, nil, &list)
 }
 
 func (s *subscriptions) ResetCursorToMessageID(topic utils.TopicName, sName string, id utils.MessageID) error {
+	return s.ResetCursorToMessageIDWithContext(context.Background(), topic, sName, id)
+}
+
+func (s *subscriptions) ResetCursorToMessageIDWithContext(ctx context.Context, topic utils.TopicName,
+	sName string, id utils.MessageID) error {
 	endpoint := s.client.endpoint(s.basePath, topic.GetRestPath(), s.SubPath, url.QueryEscape(sName), "resetcursor")
-	return s.client.post(endpoint, id)
+	return s.doWithContext(ctx, http.MethodPost, endpoint, id, nil)
 }
 
 func (s *subscriptions) ResetCursorToTimestamp(topic utils.TopicName, sName string, timestamp int64) error {
+	return s.ResetCursorToTimestampWithContext(context.Background(), topic, sName, timestamp)
+}
+
+func (s *subscriptions) ResetCursorToTimestampWithContext(ctx context.Context, topic utils.TopicName,
+	sName string, timestamp int64) error {
 	endpoint := s.client.endpoint(
 		s.basePath, topic.GetRestPath(), s.SubPath, url.QueryEscape(sName),
 		"resetcursor", strconv.FormatInt(timestamp, 10))
-	return s.client.post(endpoint, "")
+	return s.doWithContext(ctx, http.MethodPost, endpoint, nil, nil)
 }
 
 func (s *subscriptions) ClearBacklog(topic utils.TopicName, sName string) error {
+	return s.ClearBacklogWithContext(context.Background(), topic, sName)
+}
+
+func (s *subscriptions) ClearBacklogWithContext(ctx context.Context, topic utils.TopicName, sName string) error {
 	endpoint := s.client.endpoint(
 		s.basePath, topic.GetRestPath(), s.SubPath, url.QueryEscape(sName), "skip_all")
-	return s.client.post(endpoint, "")
+	return s.doWithContext(ctx, http.MethodPost, endpoint, nil, nil)
 }
 
 func (s *subscriptions) SkipMessages(topic utils.TopicName, sName string, n int64) error {
+	return s.SkipMessagesWithContext(context.Background(), topic, sName, n)
+}
+
+func (s *subscriptions) SkipMessagesWithContext(ctx context.Context, topic utils.TopicName, sName string,
+	n int64) error {
 	endpoint := s.client.endpoint(
 		s.basePath, topic.GetRestPath(), s.SubPath, url.QueryEscape(sName),
 		"skip", strconv.FormatInt(n, 10))
-	return s.client.post(endpoint, "")
+	return s.doWithContext(ctx, http.MethodPost, endpoint, nil, nil)
 }
 
 func (s *subscriptions) ExpireMessages(topic utils.TopicName, sName string, expire int64) error {
+	return s.ExpireMessagesWithContext(context.Background(), topic, sName, expire)
+}
+
+func (s *subscriptions) ExpireMessagesWithContext(ctx context.Context, topic utils.TopicName, sName string,
+	expire int64) error {
 	endpoint := s.client.endpoint(
 		s.basePath, topic.GetRestPath(), s.SubPath, url.QueryEscape(sName),
 		"expireMessages", strconv.FormatInt(expire, 10))
-	return s.client.post(endpoint, "")
+	return s.doWithContext(ctx, http.MethodPost, endpoint, nil, nil)
 }
 
 func (s *subscriptions) ExpireAllMessages(topic utils.TopicName, expire int64) error {
+	return s.ExpireAllMessagesWithContext(context.Background(), topic, expire)
+}
+
+func (s *subscriptions) ExpireAllMessagesWithContext(ctx context.Context, topic utils.TopicName,
+	expire int64) error {
 	endpoint := s.client.endpoint(
 		s.basePath, topic.GetRestPath(), "all_subscription",
 		"expireMessages", strconv.FormatInt(expire, 10))
-	return s.client.post(endpoint, "")
+	return s.doWithContext(ctx, http.MethodPost, endpoint, nil, nil)
 }
 
 func (s *subscriptions) PeekMessages(topic utils.TopicName, sName string, n int) ([]*utils.Message, error) {
-	var msgs []*utils.Message
+	return s.PeekMessagesWithContext(context.Background(), topic, sName, n)
+}
 
-	count := 1
-	for n > 0 {
-		m, err := s.peekNthMessage(topic, sName, count)
+func (s *subscriptions) PeekMessagesWithContext(ctx context.Context, topic utils.TopicName, sName string,
+	n int) ([]*utils.Message, error) {
+	it, err := s.PeekMessagesIter(topic, sName, n)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var msgs []*utils.Message
+	for {
+		m, err := it.Next(ctx)
+		if err == io.EOF {
+			return msgs, nil
+		}
 		if err != nil {
 			return nil, err
 		}
-		msgs = append(msgs, m...)
-		n -= len(m)
-		count++
+		msgs = append(msgs, m)
 	}
-
-	return msgs, nil
 }
 
-func (s *subscriptions) peekNthMessage(topic utils.TopicName, sName string, pos int) ([]*utils.Message, error) {
+func (s *subscriptions) peekNthMessage(ctx context.Context, topic utils.TopicName, sName string,
+	pos int) ([]*utils.Message, error) {
 	endpoint := s.client.endpoint(s.basePath, topic.GetRestPath(), "subscription", url.QueryEscape(sName),
 		"position", strconv.Itoa(pos))
 	req, err := s.client.newRequest(http.MethodGet, endpoint)
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
 
 	resp, err := checkSuccessful(s.client.doRequest(req))
 	if err != nil {
@@ -171,16 +302,48 @@ func (s *subscriptions) peekNthMessage(topic utils.TopicName, sName string, pos
 	}
 	defer safeRespClose(resp)
 
-	return handleResp(topic, resp)
+	return handleResp(topic, resp, s.cryptoKeyReader)
+}
+
+// doWithContext issues an HTTP request against endpoint with body as its request
+// payload, bound to ctx, and decodes the response into out when non-nil.
+func (s *subscriptions) doWithContext(ctx context.Context, method, endpoint string, body, out interface{}) error {
+	req, err := s.client.newRequest(method, endpoint)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(data))
+		req.ContentLength = int64(len(data))
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := checkSuccessful(s.client.doRequest(req))
+	if err != nil {
+		return err
+	}
+	defer safeRespClose(resp)
+
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
 }
 
 const (
-	PublishTimeHeader = "X-Pulsar-Publish-Time"
-	BatchHeader       = "X-Pulsar-Num-Batch-Message"
-	PropertyPrefix    = "X-Pulsar-PROPERTY-"
+	PublishTimeHeader     = "X-Pulsar-Publish-Time"
+	BatchHeader           = "X-Pulsar-Num-Batch-Message"
+	PropertyPrefix        = "X-Pulsar-PROPERTY-"
+	MessageMetadataHeader = "X-Pulsar-Message-Metadata"
 )
 
-func handleResp(topic utils.TopicName, resp *http.Response) ([]*utils.Message, error) {
+func handleResp(topic utils.TopicName, resp *http.Response, cryptoKeyReader CryptoKeyReader) ([]*utils.Message, error) {
 	msgID := resp.Header.Get("X-Pulsar-Message-ID")
 	ID, err := utils.ParseMessageID(msgID)
 	if err != nil {
@@ -193,6 +356,25 @@ func handleResp(topic utils.TopicName, resp *http.Response) ([]*utils.Message, e
 		return nil, err
 	}
 
+	// extractMessageMetadata must run before decryptPayload: on the embedded-metadata
+	// fallback path, the length-prefixed MessageMetadata frame is plaintext at the front
+	// of the body, ahead of the encrypted payload. Decrypting first would feed that
+	// plaintext prefix into the cipher and corrupt it.
+	meta, payload, err := extractMessageMetadata(resp.Header, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err = decryptPayload(cryptoKeyReader, resp.Header, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err = decompressPayload(meta, payload)
+	if err != nil {
+		return nil, err
+	}
+
 	properties := make(map[string]string)
 	for k := range resp.Header {
 		switch {
@@ -216,6 +398,67 @@ func handleResp(topic utils.TopicName, resp *http.Response) ([]*utils.Message, e
 	return []*utils.Message{utils.NewMessage(topic.String(), *ID, payload, properties)}, nil
 }
 
+// extractMessageMetadata locates the broker-reported MessageMetadata for a peeked
+// message, preferring the X-Pulsar-Message-Metadata response header. Older brokers
+// instead frame the metadata inline at the front of the body as a 4-byte big-endian
+// size followed by the serialized MessageMetadata, immediately before the (possibly
+// batched) message payload; extractMessageMetadata falls back to that form and returns
+// the remaining bytes as payload. It returns a nil metadata (and the payload unchanged)
+// when neither form is present.
+func extractMessageMetadata(header http.Header, payload []byte) (*utils.MessageMetadata, []byte, error) {
+	if encoded := header.Get(MessageMetadataHeader); encoded != "" {
+		meta, err := decodeMessageMetadata(encoded)
+		if err != nil {
+			return nil, nil, err
+		}
+		return meta, payload, nil
+	}
+
+	meta, rest, ok, err := parseEmbeddedMessageMetadata(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, payload, nil
+	}
+	return meta, rest, nil
+}
+
+func decodeMessageMetadata(encoded string) (*utils.MessageMetadata, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("pulsar: decoding %s header: %w", MessageMetadataHeader, err)
+	}
+
+	meta := new(utils.MessageMetadata)
+	if err := proto.Unmarshal(raw, meta); err != nil {
+		return nil, fmt.Errorf("pulsar: unmarshalling message metadata: %w", err)
+	}
+	return meta, nil
+}
+
+// parseEmbeddedMessageMetadata attempts to read a length-prefixed MessageMetadata frame
+// from the front of payload. It reports ok=false, rather than an error, when payload is
+// too short or its prefix doesn't parse as a MessageMetadata, so callers can fall back
+// to treating payload as a bare, metadata-less entry.
+func parseEmbeddedMessageMetadata(payload []byte) (meta *utils.MessageMetadata, rest []byte, ok bool, err error) {
+	if len(payload) < 4 {
+		return nil, nil, false, nil
+	}
+
+	metaSize := binary.BigEndian.Uint32(payload[:4])
+	if metaSize == 0 || int(metaSize) > len(payload)-4 {
+		return nil, nil, false, nil
+	}
+
+	m := new(utils.MessageMetadata)
+	if err := proto.Unmarshal(payload[4:4+metaSize], m); err != nil {
+		return nil, nil, false, nil
+	}
+
+	return m, payload[4+metaSize:], true, nil
+}
+
 func getIndividualMsgsFromBatch(topic utils.TopicName, msgID *utils.MessageID, data []byte,
 	properties map[string]string) ([]*utils.Message, error) {
 
@@ -223,6 +466,7 @@ func getIndividualMsgsFromBatch(topic utils.TopicName, msgID *utils.MessageID, d
 	if err != nil {
 		return nil, nil
 	}
+	msgID.BatchSize = &batchSize
 
 	msgs := make([]*utils.Message, 0, batchSize)
 
@@ -230,7 +474,8 @@ func getIndividualMsgsFromBatch(topic utils.TopicName, msgID *utils.MessageID, d
 	buf32 := make([]byte, 4)
 	rdBuf := bytes.NewReader(data)
 	for i := 0; i < batchSize; i++ {
-		msgID.BatchIndex = i
+		index := i
+		msgID.BatchIndex = &index
 		// singleMetaSize
 		if _, err := io.ReadFull(rdBuf, buf32); err != nil {
 			return nil, err
@@ -0,0 +1,78 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MessageID identifies a message stored on a topic. It is accepted by Subscriptions.Create
+// and Subscriptions.ResetCursorToMessageID to position a subscription's cursor, and is
+// returned for entries read back via PeekMessages (with BatchIndex/BatchSize populated
+// for entries of a batch).
+type MessageID struct {
+	LedgerID       int64 `json:"ledgerId"`
+	EntryID        int64 `json:"entryId"`
+	PartitionIndex int   `json:"partitionIndex"`
+
+	// BatchIndex is the position of this message within the batch entry identified by
+	// LedgerID/EntryID. It is a pointer, rather than a plain int, so that explicitly
+	// targeting the first message of a batch (BatchIndex 0) can be distinguished from not
+	// knowing the batch position at all (nil) - an int field with `omitempty` would drop
+	// the former along with the latter and silently reset to the whole entry instead.
+	BatchIndex *int `json:"batchIndex,omitempty"`
+
+	// BatchSize is the number of individual messages packed into the batch entry
+	// identified by LedgerID/EntryID. It is nil for non-batched messages.
+	BatchSize *int `json:"batchSize,omitempty"`
+}
+
+// ParseMessageID parses the "ledgerId:entryId:partitionIndex" form the broker returns in
+// the X-Pulsar-Message-ID response header of a peek request.
+func ParseMessageID(id string) (*MessageID, error) {
+	parts := strings.Split(id, ":")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("utils: invalid message id %q", id)
+	}
+
+	ledgerID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("utils: invalid ledger id in message id %q: %w", id, err)
+	}
+
+	entryID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("utils: invalid entry id in message id %q: %w", id, err)
+	}
+
+	partitionIndex := -1
+	if len(parts) > 2 {
+		partitionIndex, err = strconv.Atoi(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("utils: invalid partition index in message id %q: %w", id, err)
+		}
+	}
+
+	return &MessageID{
+		LedgerID:       ledgerID,
+		EntryID:        entryID,
+		PartitionIndex: partitionIndex,
+	}, nil
+}
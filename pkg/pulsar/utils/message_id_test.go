@@ -0,0 +1,61 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package utils
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// These cover the JSON shape ResetCursorToMessageID posts to the broker's resetcursor
+// endpoint: BatchIndex/BatchSize must be serialized when explicitly targeting a batch
+// entry, including entry 0, and omitted entirely for a plain, non-batch MessageID.
+
+func TestMessageIDMarshalOmitsBatchFieldsWhenUnset(t *testing.T) {
+	id := MessageID{LedgerID: 1, EntryID: 2, PartitionIndex: 0}
+
+	data, err := json.Marshal(id)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if strings.Contains(string(data), "batchIndex") || strings.Contains(string(data), "batchSize") {
+		t.Fatalf("Marshal(%+v) = %s, want batchIndex/batchSize omitted", id, data)
+	}
+}
+
+func TestMessageIDMarshalIncludesExplicitBatchIndexZero(t *testing.T) {
+	index, size := 0, 4
+	id := MessageID{LedgerID: 1, EntryID: 2, PartitionIndex: 0, BatchIndex: &index, BatchSize: &size}
+
+	data, err := json.Marshal(id)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if got, ok := decoded["batchIndex"]; !ok || got != float64(0) {
+		t.Fatalf("Marshal(%+v) = %s, want explicit batchIndex 0", id, data)
+	}
+	if got, ok := decoded["batchSize"]; !ok || got != float64(4) {
+		t.Fatalf("Marshal(%+v) = %s, want batchSize 4", id, data)
+	}
+}